@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+
+	"github.com/mmmorris1975/aws-runas/lib"
+)
+
+// assumeRoleLoader is a Loader which resolves credentials for profiles configured with role_arn by
+// calling STS AssumeRole.  When the profile also specifies source_profile, that profile's
+// credentials are resolved via base (the rest of the Loader chain - SSO, credential_process, and
+// so on) and used to make the AssumeRole call, so source_profile / role_arn chaining works for any
+// source profile that base can resolve.  base does not read static keys from the shared
+// credentials file, so a source_profile holding only such keys can not be used here.
+type assumeRoleLoader struct {
+	base Loader
+}
+
+// NewAssumeRoleLoader returns a Loader which resolves role_arn profiles via STS AssumeRole, using
+// base to resolve a source_profile's credentials when one is configured.
+func NewAssumeRoleLoader(base Loader) Loader {
+	return &assumeRoleLoader{base: base}
+}
+
+// Config is a no-op for assumeRoleLoader; it only resolves credentials.
+func (l *assumeRoleLoader) Config(_ string, _ ...interface{}) (*AwsConfig, error) {
+	return new(AwsConfig), nil
+}
+
+// Credentials calls STS AssumeRole for the role_arn configured on the profile's AwsConfig (found
+// among sources), first resolving source_profile credentials via base if one is configured.
+func (l *assumeRoleLoader) Credentials(profile string, sources ...interface{}) (*AwsCredentials, error) {
+	cfg, err := credentialProcessConfigFromSources(sources)
+	if err != nil {
+		return nil, fmt.Errorf("assume role loader for profile %s: %w", profile, err)
+	}
+
+	if len(cfg.RoleArn) < 1 {
+		return nil, fmt.Errorf("profile %s has no role_arn configured", profile)
+	}
+
+	awsProfile := &lib.AWSProfile{
+		Name:            cfg.Name,
+		RoleArn:         cfg.RoleArn,
+		SourceProfile:   cfg.SourceProfile,
+		ExternalId:      cfg.ExternalId,
+		MfaSerial:       cfg.MfaSerial,
+		RoleSessionName: cfg.RoleSessionName,
+		Region:          cfg.Region,
+	}
+
+	if src := cfg.SourceProfileConfig(); src != nil {
+		// Resolve by name, so base reads the source profile's full configuration (including any
+		// sso_session block it references) rather than only its raw profile section.
+		srcCreds, err := l.base.Credentials(src.Name)
+		if err != nil {
+			return nil, fmt.Errorf("resolving source_profile %s: %w", src.Name, err)
+		}
+
+		awsProfile.SourceCredentials = &credentials.Value{
+			AccessKeyID:     srcCreds.AccessKeyId,
+			SecretAccessKey: srcCreds.SecretAccessKey,
+			SessionToken:    srcCreds.SessionToken,
+		}
+	}
+
+	val, err := lib.NewAssumeRoleProvider(awsProfile, nil).Retrieve()
+	if err != nil {
+		return nil, fmt.Errorf("assuming role %s: %w", cfg.RoleArn, err)
+	}
+
+	return &AwsCredentials{
+		AccessKeyId:     val.AccessKeyID,
+		SecretAccessKey: val.SecretAccessKey,
+		SessionToken:    val.SessionToken,
+	}, nil
+}
@@ -34,8 +34,18 @@ func (l *chainLoader) Config(profile string, sources ...interface{}) (*AwsConfig
 // If an error occurs, the next loader in the chain is consulted until the end of the array.  As such, this method will
 // never return an error, but is required to satisfy the Loader interface.
 //
+// Before consulting the credential loaders, the profile's AwsConfig is resolved via Config() and appended to sources,
+// so loaders which need the profile's configuration (such as the SSO and credential_process loaders) don't have to be
+// handed it explicitly by the caller.  A source already present in sources takes precedence over this resolved value.
+//
 // Values retrieved via the various loaders are merged using the AwsCredentials.MergeIn() method
 func (l *chainLoader) Credentials(profile string, sources ...interface{}) (*AwsCredentials, error) {
+	cfg, err := l.Config(profile, sources...)
+	if err != nil {
+		logger.Debugf("error resolving configuration for profile %s: %v", profile, err)
+	}
+	sources = append(sources, cfg)
+
 	c := new(AwsCredentials)
 
 	for _, ldr := range l.loaders {
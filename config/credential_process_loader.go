@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// credentialProcessVersion is the only schema version currently defined by the AWS SDKs for the
+// credential_process JSON payload.
+const credentialProcessVersion = 1
+
+// credentialProcessOutput is the JSON schema read from (and, via WriteCredentialProcessOutput,
+// written to) a credential_process helper, as documented at
+// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// credentialProcessLoader is a Loader which resolves credentials by executing a profile's
+// `credential_process` command and parsing its JSON output.  This lets profiles delegate to
+// helpers such as aws-vault, saml2aws, or a corporate credential wrapper.
+type credentialProcessLoader struct{}
+
+// NewCredentialProcessLoader returns a Loader which executes a profile's credential_process
+// command to resolve credentials.
+func NewCredentialProcessLoader() Loader {
+	return new(credentialProcessLoader)
+}
+
+// Config is a no-op for credentialProcessLoader; the credential_process command is only ever a
+// source of credentials, never of additional configuration.
+func (l *credentialProcessLoader) Config(_ string, _ ...interface{}) (*AwsConfig, error) {
+	return new(AwsConfig), nil
+}
+
+// Credentials runs the profile's configured credential_process command and parses its output.
+// The profile's *AwsConfig, populated with CredentialProcess, must be among sources; the first one
+// found is used (the same convention used by the SSO credential provider).  chainLoader appends
+// the resolved AwsConfig to sources, so callers going through a chain need not supply it.
+func (l *credentialProcessLoader) Credentials(profile string, sources ...interface{}) (*AwsCredentials, error) {
+	cfg, err := credentialProcessConfigFromSources(sources)
+	if err != nil {
+		return nil, fmt.Errorf("credential_process loader for profile %s: %w", profile, err)
+	}
+
+	if len(cfg.CredentialProcess) < 1 {
+		return nil, fmt.Errorf("profile %s has no credential_process configured", profile)
+	}
+
+	out, err := runCredentialProcess(cfg.CredentialProcess)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &AwsCredentials{
+		AccessKeyId:     out.AccessKeyId,
+		SecretAccessKey: out.SecretAccessKey,
+		SessionToken:    out.SessionToken,
+	}
+
+	if len(out.Expiration) > 0 {
+		exp, err := time.Parse(time.RFC3339, out.Expiration)
+		if err != nil {
+			return nil, fmt.Errorf("credential_process Expiration: %w", err)
+		}
+		creds.Expiration = exp
+	}
+
+	return creds, nil
+}
+
+// runCredentialProcess executes cmd via the shell (so profiles can use quoting/arguments exactly
+// as they would on the command line) and parses its stdout as a credentialProcessOutput.
+func runCredentialProcess(cmd string) (*credentialProcessOutput, error) {
+	c := exec.Command("sh", "-c", cmd)
+
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("credential_process %q: %w: %s", cmd, err, strings.TrimSpace(stderr.String()))
+	}
+
+	out := new(credentialProcessOutput)
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return nil, fmt.Errorf("parsing credential_process output: %w", err)
+	}
+
+	return out, nil
+}
+
+func credentialProcessConfigFromSources(sources []interface{}) (*AwsConfig, error) {
+	for _, s := range sources {
+		if cfg, ok := s.(*AwsConfig); ok {
+			return cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("no *AwsConfig found in sources")
+}
+
+// WriteCredentialProcessOutput writes creds to w in the JSON schema documented for
+// credential_process, so aws-runas itself can be configured as a credential_process for any AWS
+// SDK, rather than only being usable via the IMDS/ECS credential emulation modes.
+func WriteCredentialProcessOutput(w io.Writer, creds *AwsCredentials) error {
+	out := credentialProcessOutput{
+		Version:         credentialProcessVersion,
+		AccessKeyId:     creds.AccessKeyId,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}
+
+	if !creds.Expiration.IsZero() {
+		out.Expiration = creds.Expiration.UTC().Format(time.RFC3339)
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
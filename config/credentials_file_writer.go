@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/defaults"
+)
+
+// sectionHeaderRe matches an ini-style section header line, e.g. "[default]" or "[my-profile]".
+var sectionHeaderRe = regexp.MustCompile(`^\[([^]]+)]\s*$`)
+
+// CredentialsFileWriter writes resolved temporary credentials into a named section of the shared
+// AWS credentials file, for the benefit of tools which can not use credential_process (Terraform,
+// older SDKs, and the like).  Unlike a Loader, this type only ever writes; it never reads
+// credentials back out for use by this program.
+//
+// Writes preserve every other section in the file, along with their key ordering and comments;
+// only the named section's body is replaced.
+type CredentialsFileWriter struct {
+	file string
+}
+
+// NewCredentialsFileWriter returns a CredentialsFileWriter which will update the given file.  If
+// file is empty, the AWS SDK default shared credentials file location is used.
+func NewCredentialsFileWriter(file string) *CredentialsFileWriter {
+	if len(file) < 1 {
+		file = defaults.SharedCredentialsFilename()
+	}
+	return &CredentialsFileWriter{file: file}
+}
+
+// Write atomically replaces the named section of the credentials file with creds, adding a
+// "# expires = <RFC3339>" comment line so the expiration is visible to anyone inspecting the file
+// by hand.  The file (and its parent directory) are created if they do not already exist.
+func (w *CredentialsFileWriter) Write(profile string, creds *AwsCredentials) error {
+	sections, err := parseIniSections(w.file)
+	if err != nil {
+		return fmt.Errorf("reading credentials file: %w", err)
+	}
+
+	block := credentialsBlock(profile, creds)
+
+	replaced := false
+	for i, s := range sections {
+		if s.name == profile {
+			sections[i] = block
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		sections = append(sections, block)
+	}
+
+	return writeIniSections(w.file, sections)
+}
+
+// iniSection is a named section of the credentials file, along with its raw (already formatted)
+// body, exactly as it should be re-serialized.
+type iniSection struct {
+	name string
+	body []string
+}
+
+// credentialsBlock builds the replacement section body for profile, in the same aws_access_key_id
+// / aws_secret_access_key / aws_session_token order the AWS CLI itself writes.
+func credentialsBlock(profile string, creds *AwsCredentials) iniSection {
+	body := []string{
+		fmt.Sprintf("[%s]", profile),
+		fmt.Sprintf("aws_access_key_id = %s", creds.AccessKeyId),
+		fmt.Sprintf("aws_secret_access_key = %s", creds.SecretAccessKey),
+	}
+
+	if len(creds.SessionToken) > 0 {
+		body = append(body, fmt.Sprintf("aws_session_token = %s", creds.SessionToken))
+	}
+
+	body = append(body, fmt.Sprintf("# expires = %s", creds.Expiration.UTC().Format(time.RFC3339)))
+
+	return iniSection{name: profile, body: body}
+}
+
+// parseIniSections reads file and splits it into an ordered list of sections.  Content appearing
+// before the first section header (rare, but legal ini) is kept as an unnamed leading section so
+// it is not lost on write.
+func parseIniSections(file string) ([]iniSection, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sections []iniSection
+	var cur *iniSection
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := sectionHeaderRe.FindStringSubmatch(line); m != nil {
+			if cur != nil {
+				sections = append(sections, *cur)
+			}
+			cur = &iniSection{name: m[1], body: []string{line}}
+			continue
+		}
+
+		if cur == nil {
+			cur = &iniSection{name: "", body: []string{}}
+		}
+		cur.body = append(cur.body, line)
+	}
+	if cur != nil {
+		sections = append(sections, *cur)
+	}
+
+	return sections, scanner.Err()
+}
+
+// writeIniSections atomically writes sections back out to file via a temp file plus rename, so a
+// reader never observes a partially written credentials file.
+func writeIniSections(file string, sections []iniSection) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, s := range sections {
+		for _, line := range s.body {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+	}
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// refreshJitterFrac is the largest fraction of the credentials' remaining lifetime by which the
+// background refresher wakes up ahead of expiration to fetch new credentials.  The actual amount is
+// chosen at random in [0, refreshJitterFrac), so that many processes writing the same profile
+// don't all wake, and hit the Loader, at exactly the same instant.
+const refreshJitterFrac = 0.1
+
+// minRefreshInterval is the smallest delay StartBackgroundRefresh will wait before calling
+// loader.Credentials() again.  It guards against a zero (or already-past) Expiration - from static
+// credentials, a credential_process without an Expiration, or a misbehaving Loader - turning the
+// refresh loop into an unbounded, zero-delay busy loop.
+const minRefreshInterval = 5 * time.Minute
+
+// StartBackgroundRefresh launches a goroutine which periodically calls loader.Credentials(profile)
+// and rewrites the credentials file before the current credentials expire, so consumers of the
+// file (Terraform, older SDKs, etc.) always see a usable set of keys.  The returned channel can be
+// closed by the caller to stop the refresh loop.
+func (w *CredentialsFileWriter) StartBackgroundRefresh(loader Loader, profile string) chan<- struct{} {
+	stop := make(chan struct{})
+
+	go func() {
+		for {
+			creds, err := loader.Credentials(profile)
+			if err != nil {
+				logger.Errorf("background refresh of profile %s: %v", profile, err)
+				return
+			}
+
+			if err := w.Write(profile, creds); err != nil {
+				logger.Errorf("background refresh of profile %s: %v", profile, err)
+				return
+			}
+
+			var wait time.Duration
+			if creds.Expiration.IsZero() {
+				// No expiration to race against (static credentials, or a Loader that didn't set
+				// one) - just poll for a changed value rather than spinning.
+				wait = minRefreshInterval
+			} else {
+				wait = time.Until(creds.Expiration)
+				wait -= time.Duration(float64(wait) * rand.Float64() * refreshJitterFrac)
+			}
+			if wait < minRefreshInterval {
+				wait = minRefreshInterval
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+
+	return stop
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"os"
+
+	"github.com/mbndr/logo"
+)
+
+// logger is the package-wide logger used by the various Loader implementations in this package.
+// It defaults to only printing warnings and above; callers embedding this package in a CLI can
+// swap the level with SetLogLevel.
+var logger = logo.NewSimpleLogger(os.Stderr, logo.WARN, "config", true)
+
+// SetLogLevel adjusts the verbosity of the package logger.
+func SetLogLevel(level logo.LogLevel) {
+	logger.SetLevel(level)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/go-ini/ini"
+)
+
+// SsoSession holds the values of a top-level `[sso-session name]` block, as introduced by the AWS
+// CLI v2.  A profile opts into one of these blocks by setting `sso_session = name`, which lets a
+// single device-authorization / token exchange be shared across many role profiles instead of each
+// profile re-authenticating independently.
+type SsoSession struct {
+	Name        string `ini:"-"`
+	SsoStartUrl string `ini:"sso_start_url"`
+	SsoRegion   string `ini:"sso_region"`
+}
+
+// sharedCfgLoader is a Loader which reads profile (and, for SSO profiles, sso-session) data from
+// the AWS SDK shared configuration file.  It does not read the shared credentials file, and no
+// other Loader in this module reads static keys from that file either.
+//
+// This is the only shared config file reader in the module; the previous, never-wired-up
+// lib/config.SharedCfgConfigHandler has been removed so there is a single AwsConfig and a single
+// place that knows how to populate it.
+type sharedCfgLoader struct {
+	confFile string
+}
+
+// NewSharedCfgLoader returns a Loader which reads configuration from the AWS SDK shared config
+// file.  The file location defaults to the same value the AWS SDK itself uses, honoring the
+// AWS_CONFIG_FILE environment variable.
+func NewSharedCfgLoader() Loader {
+	return &sharedCfgLoader{confFile: defaults.SharedConfigFilename()}
+}
+
+// Config returns the AwsConfig for the given profile, as read from the shared config file.  If the
+// profile references an `sso_session`, the referenced `[sso-session name]` block is merged in so
+// callers only need to look at AwsConfig to find the full SSO configuration.
+func (l *sharedCfgLoader) Config(profile string, _ ...interface{}) (*AwsConfig, error) {
+	f, err := ini.Load(l.confFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(AwsConfig)
+	if err := mapProfileSection(f, profile, c); err != nil {
+		return nil, err
+	}
+	c.Name = profile
+
+	if len(c.SsoSession) > 0 {
+		s, err := f.GetSection(fmt.Sprintf("sso-session %s", c.SsoSession))
+		if err != nil {
+			logger.Debugf("sso_session '%s' referenced by profile '%s' not found: %v", c.SsoSession, profile, err)
+			return c, nil
+		}
+
+		sess := new(SsoSession)
+		if err := s.MapTo(sess); err != nil {
+			return nil, err
+		}
+
+		if len(c.SsoStartUrl) < 1 {
+			c.SsoStartUrl = sess.SsoStartUrl
+		}
+		if len(c.SsoRegion) < 1 {
+			c.SsoRegion = sess.SsoRegion
+		}
+	}
+
+	// The SDK only honors source_profile alongside role_arn; resolve it so assume-role chaining
+	// (including chaining through an SSO source profile) has something to work with.
+	if len(c.RoleArn) > 0 && len(c.SourceProfile) > 0 {
+		src := new(AwsConfig)
+		if err := mapProfileSection(f, c.SourceProfile, src); err != nil {
+			logger.Debugf("source_profile '%s' referenced by profile '%s' not found: %v", c.SourceProfile, profile, err)
+			return c, nil
+		}
+		src.Name = c.SourceProfile
+		c.sourceProfile = src
+	}
+
+	return c, nil
+}
+
+// Credentials is a no-op for sharedCfgLoader; this Loader only deals in configuration, never
+// long-lived credential material.
+func (l *sharedCfgLoader) Credentials(_ string, _ ...interface{}) (*AwsCredentials, error) {
+	return nil, fmt.Errorf("shared config loader does not provide credentials")
+}
+
+// mapProfileSection looks up the given profile, trying the bare name first (as used for the
+// default profile) and falling back to the `profile <name>` form used by the AWS CLI for all
+// other profiles.
+func mapProfileSection(f *ini.File, profile string, c *AwsConfig) error {
+	s, err := f.GetSection(profile)
+	if err != nil {
+		s, err = f.GetSection(fmt.Sprintf("profile %s", profile))
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.MapTo(c)
+}
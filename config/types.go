@@ -0,0 +1,130 @@
+package config
+
+import "time"
+
+// Loader is implemented by the various sources of AWS configuration and credential data (the
+// shared config file, credential_process, SSO, STS AssumeRole, and so on) so they can be composed
+// together with NewChainLoader.
+type Loader interface {
+	// Config returns the AwsConfig data known to this Loader for the given profile.  The optional
+	// sources arguments allow a Loader to accept additional, implementation-specific inputs.
+	Config(profile string, sources ...interface{}) (*AwsConfig, error)
+
+	// Credentials returns the AwsCredentials data known to this Loader for the given profile.  The
+	// optional sources arguments allow a Loader to accept additional, implementation-specific inputs.
+	Credentials(profile string, sources ...interface{}) (*AwsCredentials, error)
+}
+
+// AwsConfig holds the configuration values relevant to resolving AWS credentials for a profile,
+// gathered from whichever Loader(s) a caller has chained together.
+type AwsConfig struct {
+	Name   string `ini:"-"`
+	Region string `ini:"region"`
+
+	RoleArn           string `ini:"role_arn"`
+	SourceProfile     string `ini:"source_profile"`
+	MfaSerial         string `ini:"mfa_serial"`
+	ExternalId        string `ini:"external_id"`
+	RoleSessionName   string `ini:"role_session_name"`
+	CredentialProcess string `ini:"credential_process"`
+
+	SsoStartUrl   string `ini:"sso_start_url"`
+	SsoRegion     string `ini:"sso_region"`
+	SsoAccountId  string `ini:"sso_account_id"`
+	SsoRoleName   string `ini:"sso_role_name"`
+	SsoSession    string `ini:"sso_session"`
+
+	defaultProfile *AwsConfig
+	sourceProfile  *AwsConfig
+}
+
+// SourceProfileConfig returns the resolved AwsConfig for this profile's source_profile, or nil if
+// this profile has no source_profile (or it has not been resolved by a Loader).  Loaders which
+// need a source profile's credentials - such as the assume-role Loader - use this to know which
+// profile to resolve and chain in.
+func (a *AwsConfig) SourceProfileConfig() *AwsConfig {
+	return a.sourceProfile
+}
+
+// MergeIn copies any non-zero-value fields set in c into the receiver, so multiple Loaders in a
+// chain can each contribute the pieces of configuration they know about.
+func (a *AwsConfig) MergeIn(c *AwsConfig) {
+	if c == nil {
+		return
+	}
+
+	if len(c.Name) > 0 {
+		a.Name = c.Name
+	}
+	if len(c.Region) > 0 {
+		a.Region = c.Region
+	}
+	if len(c.RoleArn) > 0 {
+		a.RoleArn = c.RoleArn
+	}
+	if len(c.SourceProfile) > 0 {
+		a.SourceProfile = c.SourceProfile
+	}
+	if len(c.MfaSerial) > 0 {
+		a.MfaSerial = c.MfaSerial
+	}
+	if len(c.ExternalId) > 0 {
+		a.ExternalId = c.ExternalId
+	}
+	if len(c.RoleSessionName) > 0 {
+		a.RoleSessionName = c.RoleSessionName
+	}
+	if len(c.CredentialProcess) > 0 {
+		a.CredentialProcess = c.CredentialProcess
+	}
+	if len(c.SsoStartUrl) > 0 {
+		a.SsoStartUrl = c.SsoStartUrl
+	}
+	if len(c.SsoRegion) > 0 {
+		a.SsoRegion = c.SsoRegion
+	}
+	if len(c.SsoAccountId) > 0 {
+		a.SsoAccountId = c.SsoAccountId
+	}
+	if len(c.SsoRoleName) > 0 {
+		a.SsoRoleName = c.SsoRoleName
+	}
+	if len(c.SsoSession) > 0 {
+		a.SsoSession = c.SsoSession
+	}
+	if c.sourceProfile != nil {
+		a.sourceProfile = c.sourceProfile
+	}
+	if c.defaultProfile != nil {
+		a.defaultProfile = c.defaultProfile
+	}
+}
+
+// AwsCredentials holds the temporary or long-term AWS credentials resolved for a profile.
+type AwsCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// MergeIn copies any non-zero-value fields set in c into the receiver, so multiple Loaders in a
+// chain can each contribute the pieces of credential data they know about.
+func (a *AwsCredentials) MergeIn(c *AwsCredentials) {
+	if c == nil {
+		return
+	}
+
+	if len(c.AccessKeyId) > 0 {
+		a.AccessKeyId = c.AccessKeyId
+	}
+	if len(c.SecretAccessKey) > 0 {
+		a.SecretAccessKey = c.SecretAccessKey
+	}
+	if len(c.SessionToken) > 0 {
+		a.SessionToken = c.SessionToken
+	}
+	if !c.Expiration.IsZero() {
+		a.Expiration = c.Expiration
+	}
+}
@@ -0,0 +1,23 @@
+package credentials
+
+import "github.com/mmmorris1975/aws-runas/config"
+
+// DefaultLoader returns the standard chain of config.Loaders used to resolve configuration and
+// credentials for a profile: the shared config file first, then credential_process (so profiles
+// delegating to helpers like aws-vault or saml2aws work transparently), then AWS SSO, then STS
+// AssumeRole for profiles configured with role_arn.  The assume-role loader is handed the rest of
+// the chain so that a role_arn profile's source_profile can itself be an SSO or
+// credential_process profile.  No Loader in this chain reads static keys from the shared
+// credentials file.
+func DefaultLoader() config.Loader {
+	base := config.NewChainLoader([]config.Loader{
+		config.NewSharedCfgLoader(),
+		config.NewCredentialProcessLoader(),
+		NewSsoCredentialProvider(),
+	})
+
+	return config.NewChainLoader([]config.Loader{
+		base,
+		config.NewAssumeRoleLoader(base),
+	})
+}
@@ -0,0 +1,15 @@
+package credentials
+
+import (
+	"os"
+
+	"github.com/mbndr/logo"
+)
+
+// logger is the package-wide logger used by the various credential providers in this package.
+var logger = logo.NewSimpleLogger(os.Stderr, logo.WARN, "credentials", true)
+
+// SetLogLevel adjusts the verbosity of the package logger.
+func SetLogLevel(level logo.LogLevel) {
+	logger.SetLevel(level)
+}
@@ -0,0 +1,47 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// OidcIdentityToken is a JWT returned from an OIDC identity provider (such as the AWS SSO OIDC
+// service, or a 3rd party IdP used for web identity federation).  It is a simple string type so
+// callers can pass it around as a raw token value, while still being able to introspect the
+// expiration claim embedded in the JWT payload.
+type OidcIdentityToken string
+
+// jwt claims we care about for determining expiration.  The JWT spec allows for many more claims
+// than this, but this is the only one we need to decide if the token is still usable.
+type oidcTokenClaims struct {
+	Exp int64 `json:"exp"`
+}
+
+// IsExpired returns true if the token is malformed, or if the "exp" claim in the token payload
+// indicates that the token has already expired.  Any error encountered while parsing the token is
+// treated as an expired token, since we can not otherwise determine that the token is still valid.
+func (t OidcIdentityToken) IsExpired() bool {
+	parts := strings.Split(string(t), ".")
+	if len(parts) != 3 {
+		return true
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return true
+	}
+
+	claims := new(oidcTokenClaims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return true
+	}
+
+	return time.Unix(claims.Exp, 0).Before(time.Now())
+}
+
+// String implements the fmt.Stringer interface, and returns the raw token value.
+func (t OidcIdentityToken) String() string {
+	return string(t)
+}
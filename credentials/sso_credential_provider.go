@@ -0,0 +1,351 @@
+package credentials
+
+import (
+	"crypto/sha1" //nolint:gosec // sha1 is the hash AWS CLI itself uses for these cache file names
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sso"
+	"github.com/aws/aws-sdk-go/service/ssooidc"
+
+	"github.com/mmmorris1975/aws-runas/config"
+)
+
+const (
+	ssoClientName = "aws-runas"
+	ssoClientType = "public"
+	ssoGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+
+	ssoPollMinInterval = 5 * time.Second
+)
+
+// clientRegistration is the on-disk cache schema for the SSO OIDC client registration, matching
+// the file the AWS CLI writes so the two tools can share a cache.
+type clientRegistration struct {
+	ClientId              string `json:"clientId"`
+	ClientSecret          string `json:"clientSecret"`
+	ClientIdIssuedAt      int64  `json:"clientIdIssuedAt"`
+	ClientSecretExpiresAt int64  `json:"clientSecretExpiresAt"`
+}
+
+func (r *clientRegistration) expired() bool {
+	return time.Unix(r.ClientSecretExpiresAt, 0).Before(time.Now())
+}
+
+// accessTokenCache is the on-disk cache schema for a resolved SSO access token, matching the file
+// the AWS CLI writes so the two tools can share a cache.
+type accessTokenCache struct {
+	StartUrl     string    `json:"startUrl"`
+	Region       string    `json:"region"`
+	AccessToken  string    `json:"accessToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ClientId     string    `json:"clientId"`
+	ClientSecret string    `json:"clientSecret"`
+}
+
+func (c *accessTokenCache) expired() bool {
+	return c.ExpiresAt.Before(time.Now())
+}
+
+// ssoCredentialProvider is a config.Loader which resolves role credentials via the AWS IAM
+// Identity Center (SSO) OIDC device authorization flow.  It caches the OIDC client registration
+// (keyed by SSO region, under a botocore-client-id-<region>.json file) and the access token
+// (keyed by sso_session, or the start URL when a profile has no sso_session) under
+// ~/.aws/sso/cache using the same JSON schema as the AWS CLI, so logging in once with either tool
+// is usable by both.
+type ssoCredentialProvider struct {
+	oidcClient   *ssooidc.SSOOIDC
+	ssoClient    *sso.SSO
+	clientRegion string
+	cacheDir     string
+}
+
+// NewSsoCredentialProvider returns a config.Loader which resolves credentials for a profile
+// configured for AWS IAM Identity Center (sso_start_url, sso_region, sso_account_id,
+// sso_role_name, and optionally sso_session).  The region used to build the OIDC/SSO clients is
+// supplied per-profile via the AwsConfig passed to Credentials(), so a single provider instance can
+// serve profiles in different SSO regions.
+func NewSsoCredentialProvider() *ssoCredentialProvider {
+	return &ssoCredentialProvider{cacheDir: ssoCacheDir()}
+}
+
+// Config is a no-op for the SSO provider; it only resolves credentials, and relies on an earlier
+// Loader in the chain (such as the shared config file Loader) to supply the sso_* values.
+func (p *ssoCredentialProvider) Config(_ string, _ ...interface{}) (*config.AwsConfig, error) {
+	return new(config.AwsConfig), nil
+}
+
+// Credentials exchanges a cached (or freshly obtained) SSO access token for role credentials via
+// sso:GetRoleCredentials.  The profile's *config.AwsConfig, populated with the sso_* values, must
+// be among sources; the first one found is used.  config.NewChainLoader appends the resolved
+// AwsConfig to sources, so callers going through a chain need not supply it.
+func (p *ssoCredentialProvider) Credentials(profile string, sources ...interface{}) (*config.AwsCredentials, error) {
+	cfg, err := ssoConfigFromSources(sources)
+	if err != nil {
+		return nil, fmt.Errorf("sso credential provider for profile %s: %w", profile, err)
+	}
+
+	if len(cfg.SsoStartUrl) < 1 || len(cfg.SsoRegion) < 1 || len(cfg.SsoRoleName) < 1 || len(cfg.SsoAccountId) < 1 {
+		return nil, fmt.Errorf("profile %s has no sso_start_url/sso_region/sso_account_id/sso_role_name configured", profile)
+	}
+
+	if err := p.ensureClients(cfg.SsoRegion); err != nil {
+		return nil, err
+	}
+
+	token, err := p.accessToken(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := p.ssoClient.GetRoleCredentials(&sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(token.AccessToken),
+		AccountId:   aws.String(cfg.SsoAccountId),
+		RoleName:    aws.String(cfg.SsoRoleName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sso GetRoleCredentials: %w", err)
+	}
+
+	rc := out.RoleCredentials
+	return &config.AwsCredentials{
+		AccessKeyId:     aws.StringValue(rc.AccessKeyId),
+		SecretAccessKey: aws.StringValue(rc.SecretAccessKey),
+		SessionToken:    aws.StringValue(rc.SessionToken),
+		Expiration:      time.UnixMilli(aws.Int64Value(rc.Expiration)),
+	}, nil
+}
+
+// ensureClients builds (or rebuilds) the OIDC/SSO clients for region.  The clients are cached, but
+// keyed by region, so a single provider instance can be safely reused across profiles configured
+// for different SSO regions - a second profile in a new region rebuilds the clients rather than
+// silently reusing the first region's.
+func (p *ssoCredentialProvider) ensureClients(region string) error {
+	if p.oidcClient != nil && p.ssoClient != nil && p.clientRegion == region {
+		return nil
+	}
+
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("building sso session: %w", err)
+	}
+
+	p.oidcClient = ssooidc.New(sess)
+	p.ssoClient = sso.New(sess)
+	p.clientRegion = region
+	return nil
+}
+
+// accessToken returns a usable, unexpired SSO access token for the given profile, reusing the
+// cached token (refreshing it via the OIDC refresh token if necessary) or falling back to a full
+// device authorization flow.
+func (p *ssoCredentialProvider) accessToken(cfg *config.AwsConfig) (*accessTokenCache, error) {
+	tokenKey := cfg.SsoSession
+	if len(tokenKey) < 1 {
+		tokenKey = cfg.SsoStartUrl
+	}
+	tokenFile := filepath.Join(p.cacheDir, cacheFileName(tokenKey))
+
+	if cached, err := readAccessTokenCache(tokenFile); err == nil && !cached.expired() {
+		return cached, nil
+	} else if err == nil && len(cached.RefreshToken) > 0 {
+		if refreshed, err := p.refreshAccessToken(cfg, cached); err == nil {
+			return refreshed, writeJsonCache(tokenFile, refreshed)
+		}
+	}
+
+	reg, err := p.clientRegistration(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tok, err := p.deviceAuthorize(cfg, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	return tok, writeJsonCache(tokenFile, tok)
+}
+
+// clientRegistration returns a cached OIDC client registration for the SSO instance, registering a
+// new one via ssooidc:RegisterClient if the cache is missing or expired.
+//
+// The registration is cached under its own file, distinct from the access token cache keyed by
+// cacheFileName(tokenKey) in accessToken() - both ultimately derive from SsoStartUrl when a
+// profile has no sso_session, and sharing one file would make each overwrite the other.
+func (p *ssoCredentialProvider) clientRegistration(cfg *config.AwsConfig) (*clientRegistration, error) {
+	regFile := filepath.Join(p.cacheDir, fmt.Sprintf("botocore-client-id-%s.json", cfg.SsoRegion))
+
+	reg := new(clientRegistration)
+	if b, err := os.ReadFile(regFile); err == nil {
+		if err := json.Unmarshal(b, reg); err == nil && !reg.expired() {
+			return reg, nil
+		}
+	}
+
+	out, err := p.oidcClient.RegisterClient(&ssooidc.RegisterClientInput{
+		ClientName: aws.String(ssoClientName),
+		ClientType: aws.String(ssoClientType),
+		Scopes:     aws.StringSlice([]string{"sso:account:access"}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sso RegisterClient: %w", err)
+	}
+
+	reg = &clientRegistration{
+		ClientId:              aws.StringValue(out.ClientId),
+		ClientSecret:          aws.StringValue(out.ClientSecret),
+		ClientIdIssuedAt:      aws.Int64Value(out.ClientIdIssuedAt),
+		ClientSecretExpiresAt: aws.Int64Value(out.ClientSecretExpiresAt),
+	}
+
+	return reg, writeJsonCache(regFile, reg)
+}
+
+// deviceAuthorize performs the OIDC device authorization flow: start a device authorization,
+// direct the user to the verification URL, and poll CreateToken until the user completes the
+// browser step or the device code expires.
+func (p *ssoCredentialProvider) deviceAuthorize(cfg *config.AwsConfig, reg *clientRegistration) (*accessTokenCache, error) {
+	da, err := p.oidcClient.StartDeviceAuthorization(&ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     aws.String(reg.ClientId),
+		ClientSecret: aws.String(reg.ClientSecret),
+		StartUrl:     aws.String(cfg.SsoStartUrl),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sso StartDeviceAuthorization: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "to complete SSO login, open the following URL in a browser: %s\n",
+		aws.StringValue(da.VerificationUriComplete))
+
+	interval := time.Duration(aws.Int64Value(da.Interval)) * time.Second
+	if interval < ssoPollMinInterval {
+		interval = ssoPollMinInterval
+	}
+	deadline := time.Now().Add(time.Duration(aws.Int64Value(da.ExpiresIn)) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		out, err := p.oidcClient.CreateToken(&ssooidc.CreateTokenInput{
+			ClientId:     aws.String(reg.ClientId),
+			ClientSecret: aws.String(reg.ClientSecret),
+			GrantType:    aws.String(ssoGrantType),
+			DeviceCode:   da.DeviceCode,
+		})
+		if err != nil {
+			if isSsoAuthPending(err) {
+				continue
+			}
+			return nil, fmt.Errorf("sso CreateToken: %w", err)
+		}
+
+		return &accessTokenCache{
+			StartUrl:     cfg.SsoStartUrl,
+			Region:       cfg.SsoRegion,
+			AccessToken:  aws.StringValue(out.AccessToken),
+			ExpiresAt:    time.Now().Add(time.Duration(aws.Int64Value(out.ExpiresIn)) * time.Second),
+			RefreshToken: aws.StringValue(out.RefreshToken),
+			ClientId:     reg.ClientId,
+			ClientSecret: reg.ClientSecret,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("timed out waiting for SSO login to complete")
+}
+
+// refreshAccessToken exchanges a cached refresh token for a new access token, avoiding a full
+// device authorization round-trip (and the accompanying browser prompt) when possible.
+func (p *ssoCredentialProvider) refreshAccessToken(cfg *config.AwsConfig, cached *accessTokenCache) (*accessTokenCache, error) {
+	out, err := p.oidcClient.CreateToken(&ssooidc.CreateTokenInput{
+		ClientId:     aws.String(cached.ClientId),
+		ClientSecret: aws.String(cached.ClientSecret),
+		GrantType:    aws.String("refresh_token"),
+		RefreshToken: aws.String(cached.RefreshToken),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sso refresh CreateToken: %w", err)
+	}
+
+	return &accessTokenCache{
+		StartUrl:     cfg.SsoStartUrl,
+		Region:       cfg.SsoRegion,
+		AccessToken:  aws.StringValue(out.AccessToken),
+		ExpiresAt:    time.Now().Add(time.Duration(aws.Int64Value(out.ExpiresIn)) * time.Second),
+		RefreshToken: aws.StringValue(out.RefreshToken),
+		ClientId:     cached.ClientId,
+		ClientSecret: cached.ClientSecret,
+	}, nil
+}
+
+func isSsoAuthPending(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == ssooidc.ErrCodeAuthorizationPendingException || aerr.Code() == ssooidc.ErrCodeSlowDownException
+	}
+	return strings.Contains(err.Error(), ssooidc.ErrCodeAuthorizationPendingException)
+}
+
+func readAccessTokenCache(file string) (*accessTokenCache, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := new(accessTokenCache)
+	if err := json.Unmarshal(b, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// writeJsonCache atomically writes v to file as JSON, via a temp file plus rename, matching the
+// scheme the AWS CLI uses for its own SSO cache files.
+func writeJsonCache(file string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// cacheFileName returns the AWS CLI compatible SSO cache file name for the given cache key
+// (typically a start URL or sso-session name): the lower-case hex sha1 digest, plus .json.
+func cacheFileName(key string) string {
+	sum := sha1.Sum([]byte(key)) //nolint:gosec // matching AWS CLI's cache file naming scheme
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+func ssoCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".aws", "sso", "cache")
+}
+
+func ssoConfigFromSources(sources []interface{}) (*config.AwsConfig, error) {
+	for _, s := range sources {
+		if cfg, ok := s.(*config.AwsConfig); ok {
+			return cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("no *config.AwsConfig found in sources")
+}
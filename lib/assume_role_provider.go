@@ -0,0 +1,219 @@
+package lib
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/mbndr/logo"
+)
+
+// ProviderName is the value returned as ProviderName in the credentials.Value built by
+// assumeRoleProvider, for consumers which branch on the source of their credentials.
+const ProviderName = "AssumeRoleProvider"
+
+const (
+	// ASSUME_ROLE_MIN_DURATION is the smallest session duration the AssumeRole API accepts.
+	ASSUME_ROLE_MIN_DURATION = 15 * time.Minute
+	// ASSUME_ROLE_MAX_DURATION is the largest session duration the AssumeRole API accepts.
+	ASSUME_ROLE_MAX_DURATION = 12 * time.Hour
+	// ASSUME_ROLE_DEFAULT_DURATION is used when a profile does not specify a duration.
+	ASSUME_ROLE_DEFAULT_DURATION = 1 * time.Hour
+)
+
+// CachedCredentialsProviderOptions configures the behavior of a cached credentials.Provider, such
+// as assumeRoleProvider.
+type CachedCredentialsProviderOptions struct {
+	// LogLevel controls the verbosity of the provider's logger.
+	LogLevel logo.LogLevel
+
+	// MaxJitterFrac, when greater than 0, reduces the requested assume-role session duration by a
+	// random fraction in [0, MaxJitterFrac) of the duration, and makes IsExpired() report true once
+	// the credentials are within MaxJitterFrac of that session duration of their expiration.  This avoids a fleet of processes assuming the same role all refreshing STS
+	// at the exact same instant.  Values are clamped to [0, 1]; out-of-range values are logged and
+	// clamped rather than treated as an error.
+	MaxJitterFrac float64
+
+	// MfaCode, if set, is used to answer an MFA challenge instead of prompting on stdin.
+	MfaCode func() (string, error)
+}
+
+// assumeRoleProvider is a credentials.Provider which retrieves temporary credentials via the STS
+// AssumeRole API, caching them to disk between invocations of the program so that a new set of
+// credentials is only requested once the cached set is expired.
+type assumeRoleProvider struct {
+	credentials.Expiry
+
+	profile *AWSProfile
+	opts    *CachedCredentialsProviderOptions
+	cacher  *credentialsCacher
+	log     *logo.Logger
+}
+
+// NewAssumeRoleProvider returns a credentials.Provider which will assume the role described by
+// profile, per the behavior configured in opts.  profile must not be nil.  A nil opts is treated
+// the same as new(CachedCredentialsProviderOptions).
+func NewAssumeRoleProvider(profile *AWSProfile, opts *CachedCredentialsProviderOptions) credentials.Provider {
+	if profile == nil {
+		panic("nil profile passed to NewAssumeRoleProvider")
+	}
+
+	if opts == nil {
+		opts = new(CachedCredentialsProviderOptions)
+	}
+
+	return &assumeRoleProvider{
+		profile: profile,
+		opts:    opts,
+		cacher:  &credentialsCacher{file: cacheFileName(profile)},
+		log:     NewLogger("AssumeRoleProvider", opts.LogLevel),
+	}
+}
+
+// IsExpired returns true if there are no cached credentials, or if the cached credentials have
+// passed (or are within the jittered window of) their expiration.
+func (p *assumeRoleProvider) IsExpired() bool {
+	cc, err := p.cacher.Load()
+	if err != nil {
+		return true
+	}
+
+	window := time.Duration(float64(cc.Duration) * p.jitterFrac())
+	return time.Now().Add(window).After(cc.Expiration)
+}
+
+// Retrieve satisfies the credentials.Provider interface, returning the cached credentials if they
+// are not expired, otherwise calling STS AssumeRole for a new set.
+func (p *assumeRoleProvider) Retrieve() (credentials.Value, error) {
+	if !p.IsExpired() {
+		if cc, err := p.cacher.Load(); err == nil {
+			return p.value(cc), nil
+		}
+	}
+
+	awsCfg := aws.NewConfig().WithRegion(p.profile.Region)
+	if p.profile.SourceCredentials != nil {
+		awsCfg = awsCfg.WithCredentials(credentials.NewStaticCredentialsFromCreds(*p.profile.SourceCredentials))
+	}
+
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("building session for assume role: %w", err)
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(p.profile.RoleArn),
+		RoleSessionName: p.validateSessionName(p.profile.RoleSessionName),
+		DurationSeconds: p.validateDuration(p.profile.Duration),
+	}
+
+	if len(p.profile.ExternalId) > 0 {
+		input.ExternalId = aws.String(p.profile.ExternalId)
+	}
+
+	if len(p.profile.MfaSerial) > 0 {
+		input.SerialNumber = aws.String(p.profile.MfaSerial)
+
+		code, err := p.mfaCode()
+		if err != nil {
+			return credentials.Value{}, fmt.Errorf("reading MFA code: %w", err)
+		}
+		input.TokenCode = aws.String(code)
+	}
+
+	out, err := sts.New(sess).AssumeRole(input)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("sts AssumeRole: %w", err)
+	}
+
+	cc := &cachedCredentials{
+		AccessKeyId:     aws.StringValue(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(out.Credentials.SessionToken),
+		Expiration:      aws.TimeValue(out.Credentials.Expiration),
+		Duration:        time.Duration(aws.Int64Value(input.DurationSeconds)) * time.Second,
+	}
+
+	if err := p.cacher.Store(cc); err != nil {
+		p.log.Errorf("error caching assume-role credentials: %v", err)
+	}
+
+	return p.value(cc), nil
+}
+
+func (p *assumeRoleProvider) value(cc *cachedCredentials) credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     cc.AccessKeyId,
+		SecretAccessKey: cc.SecretAccessKey,
+		SessionToken:    cc.SessionToken,
+		ProviderName:    ProviderName,
+	}
+}
+
+// mfaCode returns the MFA token code to use for the AssumeRole call, via the configured MfaCode
+// func, or by prompting on stdin if one was not provided.
+func (p *assumeRoleProvider) mfaCode() (string, error) {
+	if p.opts.MfaCode != nil {
+		return p.opts.MfaCode()
+	}
+
+	var code string
+	fmt.Print("Enter MFA code: ")
+	if _, err := fmt.Scanln(&code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// validateSessionName returns a usable role session name: name itself if set, otherwise a name
+// derived from the current time.
+func (p *assumeRoleProvider) validateSessionName(name string) *string {
+	if len(name) < 1 {
+		name = fmt.Sprintf("AWS-RUNAS-%d", time.Now().Unix())
+	}
+	return &name
+}
+
+// validateDuration clamps d to the [ASSUME_ROLE_MIN_DURATION, ASSUME_ROLE_MAX_DURATION] range
+// (substituting ASSUME_ROLE_DEFAULT_DURATION for a zero value), then applies the configured
+// MaxJitterFrac, if any, never reducing the result below ASSUME_ROLE_MIN_DURATION.
+func (p *assumeRoleProvider) validateDuration(d time.Duration) *int64 {
+	switch {
+	case d == 0:
+		d = ASSUME_ROLE_DEFAULT_DURATION
+	case d > ASSUME_ROLE_MAX_DURATION:
+		d = ASSUME_ROLE_MAX_DURATION
+	case d < ASSUME_ROLE_MIN_DURATION:
+		d = ASSUME_ROLE_MIN_DURATION
+	}
+
+	if frac := p.jitterFrac(); frac > 0 {
+		d -= time.Duration(rand.Float64() * frac * float64(d))
+		if d < ASSUME_ROLE_MIN_DURATION {
+			d = ASSUME_ROLE_MIN_DURATION
+		}
+	}
+
+	return aws.Int64(int64(d.Seconds()))
+}
+
+// jitterFrac returns the configured MaxJitterFrac, clamped to [0, 1].  Out-of-range values are
+// logged and clamped, rather than treated as a hard error.
+func (p *assumeRoleProvider) jitterFrac() float64 {
+	f := p.opts.MaxJitterFrac
+
+	switch {
+	case f < 0:
+		p.log.Warnf("MaxJitterFrac %f is out of range, clamping to 0", f)
+		return 0
+	case f > 1:
+		p.log.Warnf("MaxJitterFrac %f is out of range, clamping to 1", f)
+		return 1
+	default:
+		return f
+	}
+}
@@ -104,3 +104,43 @@ func TestAssumeRoleProvider_ValidateDuration(t *testing.T) {
 		}
 	})
 }
+
+func TestAssumeRoleProvider_ValidateDuration_MaxJitterFrac(t *testing.T) {
+	d := 2 * time.Hour
+
+	t.Run("JitterZero", func(t *testing.T) {
+		p := NewAssumeRoleProvider(new(AWSProfile), &CachedCredentialsProviderOptions{MaxJitterFrac: 0}).(*assumeRoleProvider)
+		i := p.validateDuration(d)
+		if *i != *aws.Int64(int64(d.Seconds())) {
+			t.Errorf("Expected unjittered duration, got %d", *i)
+		}
+	})
+
+	t.Run("JitterTenPercent", func(t *testing.T) {
+		p := NewAssumeRoleProvider(new(AWSProfile), &CachedCredentialsProviderOptions{MaxJitterFrac: 0.1}).(*assumeRoleProvider)
+
+		for n := 0; n < 25; n++ {
+			i := p.validateDuration(d)
+			min := int64(float64(d.Seconds()) * 0.9)
+			if *i < min || *i > int64(d.Seconds()) {
+				t.Errorf("Expected jittered duration between %d and %d, got %d", min, int64(d.Seconds()), *i)
+			}
+		}
+	})
+
+	t.Run("JitterOutOfRangeLow", func(t *testing.T) {
+		p := NewAssumeRoleProvider(new(AWSProfile), &CachedCredentialsProviderOptions{MaxJitterFrac: -1}).(*assumeRoleProvider)
+		i := p.validateDuration(d)
+		if *i != *aws.Int64(int64(d.Seconds())) {
+			t.Errorf("Expected negative MaxJitterFrac to be clamped to 0, got %d", *i)
+		}
+	})
+
+	t.Run("JitterOutOfRangeHigh", func(t *testing.T) {
+		p := NewAssumeRoleProvider(new(AWSProfile), &CachedCredentialsProviderOptions{MaxJitterFrac: 2}).(*assumeRoleProvider)
+		i := p.validateDuration(ASSUME_ROLE_MIN_DURATION)
+		if *i != *aws.Int64(int64(ASSUME_ROLE_MIN_DURATION.Seconds())) {
+			t.Errorf("Expected jitter to never push duration below ASSUME_ROLE_MIN_DURATION, got %d", *i)
+		}
+	})
+}
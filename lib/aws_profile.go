@@ -0,0 +1,25 @@
+package lib
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// AWSProfile holds the subset of a shared config profile's settings needed to assume a role.
+type AWSProfile struct {
+	Name            string
+	RoleArn         string
+	SourceProfile   string
+	ExternalId      string
+	MfaSerial       string
+	RoleSessionName string
+	Region          string
+	Duration        time.Duration
+
+	// SourceCredentials, if set, are used to build the session the AssumeRole call is made with,
+	// instead of the ambient/default credential chain.  This is how a source_profile resolved via
+	// a different mechanism entirely - an SSO profile, a credential_process, and so on - gets fed
+	// into the assume-role call for this profile.
+	SourceCredentials *credentials.Value
+}
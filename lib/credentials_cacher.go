@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachedCredentials is the on-disk schema used by credentialsCacher to persist temporary
+// credentials between runs of the program.
+type cachedCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+
+	// Duration is the session duration requested from STS, used to size the jitter window applied
+	// by assumeRoleProvider.IsExpired.
+	Duration time.Duration `json:",omitempty"`
+}
+
+// credentialsCacher reads and writes a single set of cachedCredentials to a file on disk.
+type credentialsCacher struct {
+	file string
+}
+
+// CacheFile returns the path of the file this credentialsCacher reads and writes.
+func (c *credentialsCacher) CacheFile() string {
+	return c.file
+}
+
+// Load reads and parses the cached credentials from disk.
+func (c *credentialsCacher) Load() (*cachedCredentials, error) {
+	b, err := os.ReadFile(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := new(cachedCredentials)
+	if err := json.Unmarshal(b, cc); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+// Store atomically writes cc to disk, via a temp file plus rename.
+func (c *credentialsCacher) Store(cc *cachedCredentials) error {
+	if err := os.MkdirAll(filepath.Dir(c.file), 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(cc)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.file)
+}
+
+// cacheFileName builds the cache file path for the given profile.  Role ARN and session name are
+// used (rather than the profile name alone) so that assuming multiple roles under one source
+// profile, or using multiple session names for the same role, do not collide on a single cache
+// file.
+func cacheFileName(p *AWSProfile) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	base := fmt.Sprintf("%s_%s", p.RoleArn, p.RoleSessionName)
+	return filepath.Join(home, ".aws", "cache", base)
+}
@@ -0,0 +1,12 @@
+package lib
+
+import (
+	"os"
+
+	"github.com/mbndr/logo"
+)
+
+// NewLogger returns a logo.Logger writing to stderr at the given level, prefixed with name.
+func NewLogger(name string, level logo.LogLevel) *logo.Logger {
+	return logo.NewSimpleLogger(os.Stderr, level, name, true)
+}
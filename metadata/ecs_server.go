@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2021 Michael Morris. All Rights Reserved.
+ *
+ * Licensed under the MIT license (the "License"). You may not use this file except in compliance
+ * with the License. A copy of the License is located at
+ *
+ * https://github.com/mmmorris1975/aws-runas/blob/master/LICENSE
+ *
+ * or in the "license" file accompanying this file. This file is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License
+ * for the specific language governing permissions and limitations under the License.
+ */
+
+package metadata
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmmorris1975/aws-runas/config"
+)
+
+// DefaultEcsCredAddr is the default address the ECS credential server binds to.  Unlike the IMDS
+// emulation in this package, the ECS-style endpoint never needs a non-loopback address, so there
+// is no interface manipulation (and no elevated privilege) required to use it.
+const DefaultEcsCredAddr = "127.0.0.1:0"
+
+// ecsCredsPath is the path prefix the AWS SDKs expect for the ECS container credentials endpoint.
+const ecsCredsPath = "/creds/"
+
+// ecsCredentials is the JSON schema served at the ECS container credentials endpoint, per
+// https://docs.aws.amazon.com/sdkref/latest/guide/feature-container-credentials.html
+type ecsCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+	RoleArn         string `json:",omitempty"`
+}
+
+// EcsCredServer is an HTTP server implementing the AWS_CONTAINER_CREDENTIALS_FULL_URI contract
+// used by ECS tasks (and honored by all current AWS SDKs).  Credentials are resolved on demand via
+// the supplied Loader, so it can serve assume-role, SSO, or any other credential chain configured
+// in this package's Loader without any of the IMDS server's interface binding requirements.
+type EcsCredServer struct {
+	loader  config.Loader
+	profile string
+	lazy    bool
+	path    string
+	token   string
+
+	listener net.Listener
+	srv      *http.Server
+
+	mu      sync.Mutex
+	creds   *config.AwsCredentials
+	roleArn string
+}
+
+// NewEcsCredServer creates a new EcsCredServer which will resolve credentials for profile via
+// loader.  If addr is empty, DefaultEcsCredAddr is used (loopback, random port).  When lazy is
+// true, credential resolution (and any accompanying MFA or SSO prompt) is deferred until the first
+// request, rather than happening at server start.
+func NewEcsCredServer(addr string, loader config.Loader, profile string, lazy bool) (*EcsCredServer, error) {
+	if len(addr) < 1 {
+		addr = DefaultEcsCredAddr
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ecs credential server listen: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+
+	s := &EcsCredServer{
+		loader:   loader,
+		profile:  profile,
+		lazy:     lazy,
+		path:     ecsCredsPath + uuid.New().String(),
+		token:    token,
+		listener: l,
+	}
+
+	if !lazy {
+		if _, _, err := s.refresh(); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Serve starts handling requests and blocks until the server is shut down, or an unrecoverable
+// error occurs.
+func (s *EcsCredServer) Serve() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.path, s.handleCreds)
+	s.srv = &http.Server{Handler: mux}
+
+	logger.Infof("ecs credential server listening on %s", s.Endpoint())
+	err := s.srv.Serve(s.listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops the server immediately, closing its listener and any active connections.
+func (s *EcsCredServer) Shutdown() error {
+	if s.srv == nil {
+		return s.listener.Close()
+	}
+	return s.srv.Close()
+}
+
+// Endpoint returns the full URI clients should use as AWS_CONTAINER_CREDENTIALS_FULL_URI.
+func (s *EcsCredServer) Endpoint() string {
+	return fmt.Sprintf("http://%s%s", s.listener.Addr().String(), s.path)
+}
+
+// AuthToken returns the value clients should use as AWS_CONTAINER_AUTHORIZATION_TOKEN.
+func (s *EcsCredServer) AuthToken() string {
+	return s.token
+}
+
+// Environ returns the environment variables an AWS SDK needs to discover and authenticate to this
+// server, suitable for appending to os.Environ() before exec'ing a child process.
+func (s *EcsCredServer) Environ() []string {
+	return []string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI=" + s.Endpoint(),
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN=" + s.AuthToken(),
+	}
+}
+
+func (s *EcsCredServer) handleCreds(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	creds, roleArn, err := s.refresh()
+	if err != nil {
+		logger.Errorf("error resolving credentials: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	body := ecsCredentials{
+		AccessKeyId:     creds.AccessKeyId,
+		SecretAccessKey: creds.SecretAccessKey,
+		Token:           creds.SessionToken,
+		Expiration:      creds.Expiration.Format(time.RFC3339),
+		RoleArn:         roleArn,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// refresh returns the cached credentials (and the profile's role_arn, if any) if they are not yet
+// expired, otherwise it resolves a new set via the configured Loader.
+func (s *EcsCredServer) refresh() (*config.AwsCredentials, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.creds != nil && time.Now().Before(s.creds.Expiration) {
+		return s.creds, s.roleArn, nil
+	}
+
+	cfg, err := s.loader.Config(s.profile)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving configuration for profile %s: %w", s.profile, err)
+	}
+
+	creds, err := s.loader.Credentials(s.profile, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(creds.AccessKeyId) < 1 || len(creds.SecretAccessKey) < 1 {
+		return nil, "", fmt.Errorf("no credentials resolved for profile %s", s.profile)
+	}
+
+	s.creds = creds
+	s.roleArn = cfg.RoleArn
+	return creds, s.roleArn, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,16 @@
+package metadata
+
+import (
+	"os"
+
+	"github.com/mbndr/logo"
+)
+
+// logger is the package-wide logger used by the IMDS and ECS credential server implementations in
+// this package.
+var logger = logo.NewSimpleLogger(os.Stderr, logo.WARN, "metadata", true)
+
+// SetLogLevel adjusts the verbosity of the package logger.
+func SetLogLevel(level logo.LogLevel) {
+	logger.SetLevel(level)
+}